@@ -0,0 +1,70 @@
+// Command glisp is a small REPL and script runner for the interpreter
+// package, used here to exercise the "file:line:col: message" error
+// formatting that position tracking exists to support.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	glisp "github.com/zhemao/glisp/interpreter"
+)
+
+// runSource parses and evaluates src, tagging tokens with path for
+// Position.File, and renders any parse or runtime error via
+// glisp.FormatError instead of printing the bare Go error.
+func runSource(env *glisp.Glisp, path, src string) error {
+	lexer := glisp.NewLexer(bufio.NewReader(strings.NewReader(src)))
+	lexer.SetFile(path)
+
+	exprs, err := glisp.ParseTokens(env, lexer)
+	if err != nil {
+		return fmt.Errorf("%s", glisp.FormatError(src, err))
+	}
+
+	if err := env.LoadExpressions(exprs); err != nil {
+		return fmt.Errorf("%s", glisp.FormatError(src, err))
+	}
+
+	if _, err := env.Run(); err != nil {
+		return fmt.Errorf("%s", glisp.FormatError(src, err))
+	}
+	return nil
+}
+
+func runFile(env *glisp.Glisp, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return runSource(env, path, string(data))
+}
+
+func repl(env *glisp.Glisp) {
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("glisp> ")
+		if !scanner.Scan() {
+			return
+		}
+		if err := runSource(env, "<repl>", scanner.Text()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+}
+
+func main() {
+	env := glisp.NewGlisp()
+
+	if len(os.Args) > 1 {
+		if err := runFile(env, os.Args[1]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	repl(env)
+}
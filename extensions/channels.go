@@ -2,6 +2,7 @@ package glispext
 
 import (
 	"fmt"
+	"reflect"
 
 	glisp "github.com/zhemao/glisp/interpreter"
 )
@@ -12,6 +13,15 @@ func (ch SexpChannel) SexpString() string {
 	return "[chan]"
 }
 
+// SexpChanClosed is returned by <! in place of the transmitted value when
+// the channel is both closed and drained, so scripts can tell a real
+// nil/null send apart from "there was nothing left to read".
+type SexpChanClosed struct{}
+
+func (SexpChanClosed) SexpString() string {
+	return "[chan closed]"
+}
+
 func MakeChanFunction(env *glisp.Glisp, name string,
 	args []glisp.Sexp) (glisp.Sexp, error) {
 	if len(args) > 1 {
@@ -31,32 +41,287 @@ func MakeChanFunction(env *glisp.Glisp, name string,
 	return SexpChannel(make(chan glisp.Sexp, size)), nil
 }
 
+func channelArg(name string, args []glisp.Sexp, idx int) (chan glisp.Sexp, error) {
+	if idx >= len(args) {
+		return nil, glisp.ErrWrongNargs
+	}
+	switch t := args[idx].(type) {
+	case SexpChannel:
+		return chan glisp.Sexp(t), nil
+	default:
+		return nil, fmt.Errorf("argument %d of %s must be channel", idx, name)
+	}
+}
+
 func ChanTxFunction(env *glisp.Glisp, name string,
 	args []glisp.Sexp) (glisp.Sexp, error) {
 	if len(args) < 1 {
 		return glisp.SexpNull, glisp.ErrWrongNargs
 	}
-	var channel chan glisp.Sexp
-	switch t := args[0].(type) {
-	case SexpChannel:
-		channel = chan glisp.Sexp(t)
-	default:
-		return glisp.SexpNull, fmt.Errorf("argument 0 of %s must be channel", name)
+	channel, err := channelArg(name, args, 0)
+	if err != nil {
+		return glisp.SexpNull, err
 	}
 
+	// env.Context() is the coroutine's cancellation context when this
+	// call is running inside one (the same context RunWithContext threads
+	// through the instruction loop), or context.Background() otherwise;
+	// selecting on ctx.Done() alongside the channel op is what lets
+	// cancel! unblock a coroutine parked in <! or send! instead of
+	// leaving it stuck on a bare blocking channel op forever.
+	ctx := env.Context()
+
 	if name == "send!" {
 		if len(args) != 2 {
 			return glisp.SexpNull, glisp.ErrWrongNargs
 		}
-		channel <- args[1]
-		return glisp.SexpNull, nil
+		select {
+		case channel <- args[1]:
+			return glisp.SexpNull, nil
+		case <-ctx.Done():
+			return glisp.SexpNull, ctx.Err()
+		}
+	}
+
+	// passing true as a second argument switches <! into :ok mode,
+	// returning (value ok?) instead of just the value; <!'s args are
+	// already evaluated by the time they reach here, so this is just a
+	// SexpBool comparison rather than a keyword/symbol check.
+	wantOk := false
+	if len(args) >= 2 {
+		flag, ok := args[1].(glisp.SexpBool)
+		if !ok || !bool(flag) {
+			return glisp.SexpNull, fmt.Errorf("argument 1 of %s must be true to request :ok mode", name)
+		}
+		wantOk = true
 	}
 
-	return <-channel, nil
+	var val glisp.Sexp
+	var ok bool
+	select {
+	case val, ok = <-channel:
+		if !ok {
+			val = SexpChanClosed{}
+		}
+	case <-ctx.Done():
+		return glisp.SexpNull, ctx.Err()
+	}
+
+	if !wantOk {
+		return val, nil
+	}
+	return glisp.MakeList([]glisp.Sexp{val, glisp.SexpBool(ok)}), nil
+}
+
+func CloseChanFunction(env *glisp.Glisp, name string,
+	args []glisp.Sexp) (glisp.Sexp, error) {
+	if len(args) != 1 {
+		return glisp.SexpNull, glisp.ErrWrongNargs
+	}
+	channel, err := channelArg(name, args, 0)
+	if err != nil {
+		return glisp.SexpNull, err
+	}
+
+	close(channel)
+	return glisp.SexpNull, nil
+}
+
+// selectCaseKind distinguishes the three clause shapes select! accepts.
+type selectCaseKind int
+
+const (
+	selectRecv selectCaseKind = iota
+	selectSend
+	selectDefault
+)
+
+// SexpSelectCase is the runtime descriptor select-case-recv,
+// select-case-send, and select-case-default build for __select-dispatch__
+// to turn into a reflect.SelectCase.
+type SexpSelectCase struct {
+	kind    selectCaseKind
+	channel chan glisp.Sexp
+	sendVal glisp.Sexp
+}
+
+func (SexpSelectCase) SexpString() string {
+	return "[select-case]"
+}
+
+func SelectCaseRecvFunction(env *glisp.Glisp, name string,
+	args []glisp.Sexp) (glisp.Sexp, error) {
+	channel, err := channelArg(name, args, 0)
+	if err != nil {
+		return glisp.SexpNull, err
+	}
+	return SexpSelectCase{kind: selectRecv, channel: channel}, nil
+}
+
+func SelectCaseSendFunction(env *glisp.Glisp, name string,
+	args []glisp.Sexp) (glisp.Sexp, error) {
+	channel, err := channelArg(name, args, 0)
+	if err != nil {
+		return glisp.SexpNull, err
+	}
+	if len(args) != 2 {
+		return glisp.SexpNull, glisp.ErrWrongNargs
+	}
+	return SexpSelectCase{kind: selectSend, channel: channel, sendVal: args[1]}, nil
+}
+
+func SelectCaseDefaultFunction(env *glisp.Glisp, name string,
+	args []glisp.Sexp) (glisp.Sexp, error) {
+	return SexpSelectCase{kind: selectDefault}, nil
+}
+
+// SelectDispatchFunction runs reflect.Select over the cases built by the
+// select-case-* helpers and applies the winning clause's closure, which
+// select! compiles each clause body down to. A recv clause's closure
+// takes the received value (or SexpChanClosed if the channel was
+// closed); send and default clauses take no arguments.
+func SelectDispatchFunction(env *glisp.Glisp, name string,
+	args []glisp.Sexp) (glisp.Sexp, error) {
+	if len(args) < 1 {
+		return glisp.SexpNull, glisp.ErrWrongNargs
+	}
+
+	caseExprs, err := glisp.ListToArray(args[0])
+	if err != nil {
+		return glisp.SexpNull, err
+	}
+	bodies := args[1:]
+	if len(caseExprs) != len(bodies) {
+		return glisp.SexpNull, fmt.Errorf("%s: %d cases but %d bodies", name, len(caseExprs), len(bodies))
+	}
+
+	cases := make([]reflect.SelectCase, len(caseExprs))
+	descs := make([]SexpSelectCase, len(caseExprs))
+	for i, ce := range caseExprs {
+		desc, ok := ce.(SexpSelectCase)
+		if !ok {
+			return glisp.SexpNull, fmt.Errorf("%s: argument %d is not a select case", name, i)
+		}
+		descs[i] = desc
+
+		switch desc.kind {
+		case selectRecv:
+			cases[i] = reflect.SelectCase{
+				Dir:  reflect.SelectRecv,
+				Chan: reflect.ValueOf(desc.channel),
+			}
+		case selectSend:
+			cases[i] = reflect.SelectCase{
+				Dir:  reflect.SelectSend,
+				Chan: reflect.ValueOf(desc.channel),
+				Send: reflect.ValueOf(desc.sendVal),
+			}
+		case selectDefault:
+			cases[i] = reflect.SelectCase{Dir: reflect.SelectDefault}
+		}
+	}
+
+	chosen, recv, recvOK := reflect.Select(cases)
+
+	fun, ok := bodies[chosen].(glisp.SexpFunction)
+	if !ok {
+		return glisp.SexpNull, fmt.Errorf("%s: clause %d body is not a function", name, chosen)
+	}
+
+	switch descs[chosen].kind {
+	case selectRecv:
+		var val glisp.Sexp = SexpChanClosed{}
+		if recvOK {
+			val = recv.Interface().(glisp.Sexp)
+		}
+		return env.Apply(fun, []glisp.Sexp{val})
+	default:
+		return env.Apply(fun, []glisp.Sexp{})
+	}
+}
+
+// isKeywordSym reports whether expr is the bare symbol name, used to
+// recognize the send and default clause tags in select! without
+// requiring a dedicated keyword token type. expr comes straight from
+// the parser, so it must be unwrapped before the type assertion.
+func isKeywordSym(expr glisp.Sexp, name string) bool {
+	sym, ok := glisp.Unwrap(expr).(glisp.SexpSymbol)
+	return ok && sym.Name() == name
+}
+
+// SelectMacro compiles
+//
+//	(select! [ch1 v] body1 [send ch2 expr] body2 [default] body3)
+//
+// into a call to __select-dispatch__ with a list of select-case-* case
+// descriptors and one zero/one-arg closure per clause body, so the
+// actual multi-channel wait happens in SelectDispatchFunction at call
+// time via reflect.Select.
+func SelectMacro(env *glisp.Glisp, name string,
+	args []glisp.Sexp) (glisp.Sexp, error) {
+	if len(args)%2 != 0 {
+		return glisp.SexpNull, fmt.Errorf("%s requires clause/body pairs", name)
+	}
+
+	cases := make([]glisp.Sexp, 0, len(args)/2)
+	closures := make([]glisp.Sexp, 0, len(args)/2)
+
+	for i := 0; i < len(args); i += 2 {
+		clause, ok := glisp.Unwrap(args[i]).(glisp.SexpArray)
+		if !ok {
+			return glisp.SexpNull, fmt.Errorf("%s: clause %d must be an array", name, i/2)
+		}
+		body := args[i+1]
+
+		switch {
+		case len(clause) == 1 && isKeywordSym(clause[0], "default"):
+			cases = append(cases, glisp.MakeList([]glisp.Sexp{env.MakeSymbol("select-case-default")}))
+			closures = append(closures, glisp.MakeList([]glisp.Sexp{
+				env.MakeSymbol("fn"), glisp.SexpArray{}, body,
+			}))
+		case len(clause) == 3 && isKeywordSym(clause[0], "send"):
+			cases = append(cases, glisp.MakeList([]glisp.Sexp{
+				env.MakeSymbol("select-case-send"), clause[1], clause[2],
+			}))
+			closures = append(closures, glisp.MakeList([]glisp.Sexp{
+				env.MakeSymbol("fn"), glisp.SexpArray{}, body,
+			}))
+		case len(clause) == 2:
+			cases = append(cases, glisp.MakeList([]glisp.Sexp{
+				env.MakeSymbol("select-case-recv"), clause[0],
+			}))
+			closures = append(closures, glisp.MakeList([]glisp.Sexp{
+				env.MakeSymbol("fn"), glisp.SexpArray{clause[1]}, body,
+			}))
+		default:
+			return glisp.SexpNull, fmt.Errorf("%s: invalid clause %d", name, i/2)
+		}
+	}
+
+	// cases holds case-building call forms like (select-case-recv ch1);
+	// wrap them in a call to list so the evaluator runs each one and
+	// collects the results into a runtime list, instead of trying to
+	// evaluate the bare nested forms as code in their own right.
+	caseList := make([]glisp.Sexp, 0, 1+len(cases))
+	caseList = append(caseList, env.MakeSymbol("list"))
+	caseList = append(caseList, cases...)
+
+	call := make([]glisp.Sexp, 0, 2+len(closures))
+	call = append(call, env.MakeSymbol("__select-dispatch__"), glisp.MakeList(caseList))
+	call = append(call, closures...)
+
+	return glisp.MakeList(call), nil
 }
 
 func ImportChannels(env *glisp.Glisp) {
 	env.AddFunction("make-chan", MakeChanFunction)
 	env.AddFunction("send!", ChanTxFunction)
 	env.AddFunction("<!", ChanTxFunction)
+	env.AddFunction("close!", CloseChanFunction)
+
+	env.AddFunction("select-case-recv", SelectCaseRecvFunction)
+	env.AddFunction("select-case-send", SelectCaseSendFunction)
+	env.AddFunction("select-case-default", SelectCaseDefaultFunction)
+	env.AddFunction("__select-dispatch__", SelectDispatchFunction)
+	env.AddMacro("select!", SelectMacro)
 }
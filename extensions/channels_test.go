@@ -0,0 +1,135 @@
+package glispext
+
+import (
+	"testing"
+
+	glisp "github.com/zhemao/glisp/interpreter"
+)
+
+func TestSelectRecvClause(t *testing.T) {
+	env := newTestEnv()
+
+	if _, err := runSource(t, env, `
+		(def ch (make-chan 1))
+		(send! ch 42)
+	`); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	result, err := runSource(t, env, `(select! [ch v] v)`)
+	if err != nil {
+		t.Fatalf("select! recv clause: %v", err)
+	}
+	if i, ok := result.(glisp.SexpInt); !ok || i != 42 {
+		t.Fatalf("expected the recv clause to bind v to 42, got %#v", result)
+	}
+}
+
+func TestSelectSendClause(t *testing.T) {
+	env := newTestEnv()
+
+	if _, err := runSource(t, env, `(def ch (make-chan 1))`); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	if _, err := runSource(t, env, `(select! [send ch 7] 111)`); err != nil {
+		t.Fatalf("select! send clause: %v", err)
+	}
+
+	result, err := runSource(t, env, `(<! ch)`)
+	if err != nil {
+		t.Fatalf("<!: %v", err)
+	}
+	if i, ok := result.(glisp.SexpInt); !ok || i != 7 {
+		t.Fatalf("expected the send clause to have put 7 on ch, got %#v", result)
+	}
+}
+
+// TestSelectDefaultClause uses an unbuffered channel with no sender, so
+// the recv clause can never be ready and the default clause must fire.
+func TestSelectDefaultClause(t *testing.T) {
+	env := newTestEnv()
+
+	if _, err := runSource(t, env, `(def ch (make-chan))`); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	result, err := runSource(t, env, `(select! [ch v] v [default] 999)`)
+	if err != nil {
+		t.Fatalf("select! default clause: %v", err)
+	}
+	if i, ok := result.(glisp.SexpInt); !ok || i != 999 {
+		t.Fatalf("expected the default clause to fire since ch was never ready, got %#v", result)
+	}
+}
+
+func TestCloseChanReadsAsSentinel(t *testing.T) {
+	env := newTestEnv()
+
+	if _, err := runSource(t, env, `
+		(def ch (make-chan 1))
+		(close! ch)
+	`); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	result, err := runSource(t, env, `(<! ch)`)
+	if err != nil {
+		t.Fatalf("<!: %v", err)
+	}
+	if _, ok := result.(SexpChanClosed); !ok {
+		t.Fatalf("expected a read of a closed, drained channel to return the closed sentinel, got %#v", result)
+	}
+}
+
+// TestChanTxOkMode checks the :ok flag's value/false -> value/true split:
+// a real value reads back with ok=true, a closed channel's sentinel
+// reads back with ok=false.
+func TestChanTxOkMode(t *testing.T) {
+	env := newTestEnv()
+
+	if _, err := runSource(t, env, `
+		(def ch (make-chan 1))
+		(send! ch 5)
+		(def closed-ch (make-chan 1))
+		(close! closed-ch)
+	`); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	result, err := runSource(t, env, `(<! ch true)`)
+	if err != nil {
+		t.Fatalf("<! ok-mode: %v", err)
+	}
+	arr, err := glisp.ListToArray(result)
+	if err != nil {
+		t.Fatalf("ListToArray: %v", err)
+	}
+	if len(arr) != 2 {
+		t.Fatalf("expected (value ok?), got %v", arr)
+	}
+	if i, ok := arr[0].(glisp.SexpInt); !ok || i != 5 {
+		t.Fatalf("expected value 5, got %#v", arr[0])
+	}
+	if b, ok := arr[1].(glisp.SexpBool); !ok || !bool(b) {
+		t.Fatalf("expected ok=true for a real value, got %#v", arr[1])
+	}
+
+	result, err = runSource(t, env, `(<! closed-ch true)`)
+	if err != nil {
+		t.Fatalf("<! ok-mode on closed channel: %v", err)
+	}
+	arr, err = glisp.ListToArray(result)
+	if err != nil {
+		t.Fatalf("ListToArray: %v", err)
+	}
+	if len(arr) != 2 {
+		t.Fatalf("expected (value ok?), got %v", arr)
+	}
+	if _, ok := arr[0].(SexpChanClosed); !ok {
+		t.Fatalf("expected the closed sentinel as the value, got %#v", arr[0])
+	}
+	if b, ok := arr[1].(glisp.SexpBool); !ok || bool(b) {
+		t.Fatalf("expected ok=false for a closed channel, got %#v", arr[1])
+	}
+}
@@ -1,30 +1,71 @@
 package glispext
 
 import (
+	"context"
 	"errors"
+	"fmt"
 
 	glisp "github.com/zhemao/glisp/interpreter"
 )
 
+// SexpCoroutine is a running (or finished) coroutine started by the go
+// macro. It carries its own cancellation context so cancel! can ask it
+// to stop, a done channel join! can wait on, and a result slot holding
+// whatever Run() returned -- including a recovered panic, converted to
+// a glisp error instead of crashing the host process.
 type SexpCoroutine struct {
-	env *glisp.Glisp
+	env    *glisp.Glisp
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	result *coroResult
+}
+
+type coroResult struct {
+	value glisp.Sexp
+	err   error
 }
 
 func (coro SexpCoroutine) SexpString() string {
 	return "[coroutine]"
 }
 
+var ErrCoroutineNotDone = errors.New("coroutine has not finished")
+var ErrNotACoroutine = errors.New("not a coroutine")
+
+// StartCoroutineFunction launches t in its own goroutine and returns t
+// itself, so that (go ...) -- which expands to (apply __start [coro]) --
+// evaluates to the SexpCoroutine rather than SexpNull, making it
+// bindable and usable with cancel!/join!/alive?/coroutine-error.
 func StartCoroutineFunction(env *glisp.Glisp, name string,
 	args []glisp.Sexp) (glisp.Sexp, error) {
 	switch t := args[0].(type) {
 	case SexpCoroutine:
-		go func() {
-			_, _ = t.env.Run()
-		}()
+		go runCoroutine(t)
+		return t, nil
 	default:
-		return glisp.SexpNull, errors.New("not a coroutine")
+		return glisp.SexpNull, ErrNotACoroutine
 	}
-	return glisp.SexpNull, nil
+}
+
+// runCoroutine drives the coroutine's environment to completion,
+// recovering a panic into the result's error instead of taking down the
+// host process, and always closes done so join! can't block forever.
+func runCoroutine(coro SexpCoroutine) {
+	defer close(coro.done)
+	defer func() {
+		if r := recover(); r != nil {
+			coro.result.value = glisp.SexpNull
+			coro.result.err = fmt.Errorf("coroutine panic: %v", r)
+		}
+	}()
+
+	// RunWithContext threads coro.ctx through the instruction loop so it
+	// can poll ctx.Done() between instructions and unwind cooperatively
+	// when cancel! fires.
+	value, err := coro.env.RunWithContext(coro.ctx)
+	coro.result.value = value
+	coro.result.err = err
 }
 
 func CreateCoroutineMacro(env *glisp.Glisp, name string,
@@ -32,9 +73,17 @@ func CreateCoroutineMacro(env *glisp.Glisp, name string,
 	coroenv := env.Duplicate()
 	err := coroenv.LoadExpressions(args)
 	if err != nil {
-		return glisp.SexpNull, nil
+		return glisp.SexpNull, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	coro := SexpCoroutine{
+		env:    coroenv,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		result: &coroResult{},
 	}
-	coro := SexpCoroutine{coroenv}
 
 	// (apply StartCoroutineFunction [coro])
 	return glisp.MakeList([]glisp.Sexp{env.MakeSymbol("apply"),
@@ -42,6 +91,86 @@ func CreateCoroutineMacro(env *glisp.Glisp, name string,
 		glisp.SexpArray([]glisp.Sexp{coro})}), nil
 }
 
+func coroutineArg(name string, args []glisp.Sexp) (SexpCoroutine, error) {
+	if len(args) != 1 {
+		return SexpCoroutine{}, glisp.ErrWrongNargs
+	}
+	coro, ok := args[0].(SexpCoroutine)
+	if !ok {
+		return SexpCoroutine{}, fmt.Errorf("argument to %s must be a coroutine", name)
+	}
+	return coro, nil
+}
+
+func CancelCoroutineFunction(env *glisp.Glisp, name string,
+	args []glisp.Sexp) (glisp.Sexp, error) {
+	coro, err := coroutineArg(name, args)
+	if err != nil {
+		return glisp.SexpNull, err
+	}
+	coro.cancel()
+	return glisp.SexpNull, nil
+}
+
+// JoinCoroutineFunction blocks until coro finishes, then returns the
+// value of its last expression, or re-raises its error (including a
+// recovered panic, or context.Canceled after cancel!) to the caller.
+func JoinCoroutineFunction(env *glisp.Glisp, name string,
+	args []glisp.Sexp) (glisp.Sexp, error) {
+	coro, err := coroutineArg(name, args)
+	if err != nil {
+		return glisp.SexpNull, err
+	}
+
+	<-coro.done
+
+	if coro.result.err != nil {
+		return glisp.SexpNull, coro.result.err
+	}
+	return coro.result.value, nil
+}
+
+func AliveCoroutineFunction(env *glisp.Glisp, name string,
+	args []glisp.Sexp) (glisp.Sexp, error) {
+	coro, err := coroutineArg(name, args)
+	if err != nil {
+		return glisp.SexpNull, err
+	}
+
+	select {
+	case <-coro.done:
+		return glisp.SexpBool(false), nil
+	default:
+		return glisp.SexpBool(true), nil
+	}
+}
+
+// CoroutineErrorFunction returns the error (if any) coro finished with,
+// as a string, or SexpNull if it finished cleanly. It returns
+// ErrCoroutineNotDone if the coroutine is still running.
+func CoroutineErrorFunction(env *glisp.Glisp, name string,
+	args []glisp.Sexp) (glisp.Sexp, error) {
+	coro, err := coroutineArg(name, args)
+	if err != nil {
+		return glisp.SexpNull, err
+	}
+
+	select {
+	case <-coro.done:
+	default:
+		return glisp.SexpNull, ErrCoroutineNotDone
+	}
+
+	if coro.result.err == nil {
+		return glisp.SexpNull, nil
+	}
+	return glisp.SexpStr(coro.result.err.Error()), nil
+}
+
 func ImportCoroutines(env *glisp.Glisp) {
 	env.AddMacro("go", CreateCoroutineMacro)
+	env.AddFunction("cancel!", CancelCoroutineFunction)
+	env.AddFunction("join!", JoinCoroutineFunction)
+	env.AddFunction("alive?", AliveCoroutineFunction)
+	env.AddFunction("coroutine-error", CoroutineErrorFunction)
 }
@@ -0,0 +1,101 @@
+package glispext
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	glisp "github.com/zhemao/glisp/interpreter"
+)
+
+func newTestEnv() *glisp.Glisp {
+	env := glisp.NewGlisp()
+	ImportCoroutines(env)
+	ImportChannels(env)
+	return env
+}
+
+func runSource(t *testing.T, env *glisp.Glisp, src string) (glisp.Sexp, error) {
+	t.Helper()
+	lexer := glisp.NewLexer(bufio.NewReader(strings.NewReader(src)))
+	exprs, err := glisp.ParseTokens(env, lexer)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := env.LoadExpressions(exprs); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	return env.Run()
+}
+
+// TestJoinFanOutFanIn spawns a handful of coroutines that each send
+// their index onto a shared channel, then fans them back in through
+// join!, checking that each receive on the channel and the join! of
+// every coroutine completes without error. The received values and
+// the values join! returns are not compared.
+func TestJoinFanOutFanIn(t *testing.T) {
+	env := newTestEnv()
+
+	_, err := runSource(t, env, `
+		(def results-chan (make-chan 4))
+		(def coros
+		  (map (fn [i]
+		         (go (send! results-chan i) i))
+		       [0 1 2 3]))
+	`)
+	if err != nil {
+		t.Fatalf("fan-out: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := runSource(t, env, `(<! results-chan)`); err != nil {
+			t.Fatalf("fan-in recv %d: %v", i, err)
+		}
+	}
+
+	if _, err := runSource(t, env, `(map join! coros)`); err != nil {
+		t.Fatalf("join! fan-in: %v", err)
+	}
+}
+
+// TestCancelJoinTimesOut starts a coroutine that blocks forever on an
+// empty channel, cancels it after a context.WithTimeout deadline, and
+// checks that join! returns rather than hanging, and that
+// coroutine-error can then be called without error. Neither the error
+// join! returns nor the text coroutine-error reports is compared.
+func TestCancelJoinTimesOut(t *testing.T) {
+	env := newTestEnv()
+
+	if _, err := runSource(t, env, `
+		(def blocker-chan (make-chan))
+		(def coro (go (<! blocker-chan)))
+	`); err != nil {
+		t.Fatalf("spawn: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+
+	if _, err := runSource(t, env, `(cancel! coro)`); err != nil {
+		t.Fatalf("cancel!: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		_, _ = runSource(t, env, `(join! coro)`)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("join! did not return after cancel!")
+	}
+
+	if _, err := runSource(t, env, `(coroutine-error coro)`); err != nil {
+		t.Fatalf("coroutine-error: %v", err)
+	}
+}
@@ -0,0 +1,355 @@
+package glisp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+type TokenType int
+
+const (
+	TokenLParen TokenType = iota
+	TokenRParen
+	TokenLSquare
+	TokenRSquare
+	TokenLCurly
+	TokenRCurly
+	TokenQuote
+	TokenBacktick
+	TokenTilde
+	TokenTildeAt
+	TokenDot
+	TokenSymbol
+	TokenBool
+	TokenDecimal
+	TokenHex
+	TokenOct
+	TokenBinary
+	TokenChar
+	TokenString
+	TokenFloat
+	TokenEnd
+)
+
+type Token struct {
+	typ TokenType
+	str string
+}
+
+// Lexer turns a source reader into a stream of Tokens. It buffers one
+// token of lookahead, scanned on demand, so Pos can report the Position
+// of whatever token PeekNextToken/GetNextToken will return next, which is
+// how the parser attaches a Position to every node it builds.
+type Lexer struct {
+	reader *bufio.Reader
+	file   string
+
+	line   int
+	col    int
+	offset int
+
+	// line/col before the last readRune, restored by unreadRune
+	prevLine int
+	prevCol  int
+
+	next    *Token
+	nextPos Position
+	nextErr error
+}
+
+func NewLexer(reader *bufio.Reader) *Lexer {
+	return &Lexer{reader: reader, line: 1, col: 1}
+}
+
+// SetFile records the source file name, used to fill Position.File in
+// every Position produced from here on.
+func (lx *Lexer) SetFile(file string) {
+	lx.file = file
+}
+
+// Pos returns the Position of the token that the next call to
+// PeekNextToken or GetNextToken will return.
+func (lx *Lexer) Pos() Position {
+	lx.fill()
+	return lx.nextPos
+}
+
+func (lx *Lexer) PeekNextToken() (Token, error) {
+	lx.fill()
+	if lx.nextErr != nil {
+		return Token{}, lx.nextErr
+	}
+	return *lx.next, nil
+}
+
+func (lx *Lexer) GetNextToken() (Token, error) {
+	lx.fill()
+	if lx.nextErr != nil {
+		return Token{}, lx.nextErr
+	}
+	tok := *lx.next
+	lx.next = nil
+	return tok, nil
+}
+
+// fill ensures lx.next/lx.nextPos (or lx.nextErr) are populated with the
+// next token, scanning it from the reader if necessary.
+func (lx *Lexer) fill() {
+	if lx.next != nil || lx.nextErr != nil {
+		return
+	}
+	tok, pos, err := lx.scanToken()
+	if err != nil {
+		lx.nextErr = err
+		return
+	}
+	lx.next = &tok
+	lx.nextPos = pos
+}
+
+func (lx *Lexer) readRune() (rune, error) {
+	r, _, err := lx.reader.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	lx.prevLine, lx.prevCol = lx.line, lx.col
+	lx.offset++
+	if r == '\n' {
+		lx.line++
+		lx.col = 1
+	} else {
+		lx.col++
+	}
+	return r, nil
+}
+
+func (lx *Lexer) unreadRune() {
+	_ = lx.reader.UnreadRune()
+	lx.offset--
+	lx.line, lx.col = lx.prevLine, lx.prevCol
+}
+
+func (lx *Lexer) peekRune() (rune, error) {
+	r, _, err := lx.reader.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	_ = lx.reader.UnreadRune()
+	return r, nil
+}
+
+func (lx *Lexer) pos() Position {
+	return Position{File: lx.file, Line: lx.line, Col: lx.col, Offset: lx.offset}
+}
+
+const atomDelims = "()[]{}'`~\";"
+
+func isDelim(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(atomDelims, r)
+}
+
+// scanToken skips whitespace and comments, then reads exactly one
+// token, returning its Position (the position it started at).
+func (lx *Lexer) scanToken() (Token, Position, error) {
+	for {
+		start := lx.pos()
+		r, err := lx.readRune()
+		if err == io.EOF {
+			return Token{typ: TokenEnd}, start, nil
+		}
+		if err != nil {
+			return Token{}, Position{}, err
+		}
+
+		if unicode.IsSpace(r) {
+			continue
+		}
+
+		if r == ';' {
+			for {
+				c, err := lx.readRune()
+				if err != nil || c == '\n' {
+					break
+				}
+			}
+			continue
+		}
+
+		switch r {
+		case '(':
+			return Token{typ: TokenLParen}, start, nil
+		case ')':
+			return Token{typ: TokenRParen}, start, nil
+		case '[':
+			return Token{typ: TokenLSquare}, start, nil
+		case ']':
+			return Token{typ: TokenRSquare}, start, nil
+		case '{':
+			return Token{typ: TokenLCurly}, start, nil
+		case '}':
+			return Token{typ: TokenRCurly}, start, nil
+		case '\'':
+			return Token{typ: TokenQuote}, start, nil
+		case '`':
+			return Token{typ: TokenBacktick}, start, nil
+		case '~':
+			next, err := lx.peekRune()
+			if err == nil && next == '@' {
+				_, _ = lx.readRune()
+				return Token{typ: TokenTildeAt}, start, nil
+			}
+			return Token{typ: TokenTilde}, start, nil
+		case '"':
+			str, err := lx.scanString()
+			if err != nil {
+				return Token{}, start, err
+			}
+			return Token{typ: TokenString, str: str}, start, nil
+		case '\\':
+			c, err := lx.readRune()
+			if err != nil {
+				return Token{}, start, fmt.Errorf("unterminated character literal")
+			}
+			return Token{typ: TokenChar, str: string(c)}, start, nil
+		case '.':
+			next, err := lx.peekRune()
+			if err != nil || isDelim(next) {
+				return Token{typ: TokenDot}, start, nil
+			}
+			// peekRune has used up the reader's single unread, so the
+			// dot is prepended here rather than unread and re-scanned
+			atom := "." + lx.scanAtom()
+			return classifyAtom(atom), start, nil
+		default:
+			lx.unreadRune()
+			atom := lx.scanAtom()
+			return classifyAtom(atom), start, nil
+		}
+	}
+}
+
+func (lx *Lexer) scanString() (string, error) {
+	var sb strings.Builder
+	for {
+		r, err := lx.readRune()
+		if err != nil {
+			return "", fmt.Errorf("unterminated string literal")
+		}
+		if r == '"' {
+			return sb.String(), nil
+		}
+		if r == '\\' {
+			esc, err := lx.readRune()
+			if err != nil {
+				return "", fmt.Errorf("unterminated string literal")
+			}
+			switch esc {
+			case 'n':
+				sb.WriteRune('\n')
+			case 't':
+				sb.WriteRune('\t')
+			case '"':
+				sb.WriteRune('"')
+			case '\\':
+				sb.WriteRune('\\')
+			default:
+				sb.WriteRune(esc)
+			}
+			continue
+		}
+		sb.WriteRune(r)
+	}
+}
+
+func (lx *Lexer) scanAtom() string {
+	var sb strings.Builder
+	for {
+		r, err := lx.readRune()
+		if err != nil {
+			break
+		}
+		if isDelim(r) {
+			lx.unreadRune()
+			break
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func classifyAtom(atom string) Token {
+	switch atom {
+	case "true", "false":
+		return Token{typ: TokenBool, str: atom}
+	}
+
+	if len(atom) == 0 {
+		return Token{typ: TokenSymbol, str: atom}
+	}
+
+	neg := strings.HasPrefix(atom, "-")
+	digits := atom
+	if neg {
+		digits = atom[1:]
+	}
+
+	switch {
+	case strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X"):
+		return Token{typ: TokenHex, str: signPrefix(neg) + digits[2:]}
+	case strings.HasPrefix(digits, "0b") || strings.HasPrefix(digits, "0B"):
+		return Token{typ: TokenBinary, str: signPrefix(neg) + digits[2:]}
+	case strings.HasPrefix(digits, "0o") || strings.HasPrefix(digits, "0O"):
+		return Token{typ: TokenOct, str: signPrefix(neg) + digits[2:]}
+	}
+
+	if isAllDigits(digits) {
+		return Token{typ: TokenDecimal, str: atom}
+	}
+
+	if isFloatLiteral(digits) {
+		return Token{typ: TokenFloat, str: atom}
+	}
+
+	return Token{typ: TokenSymbol, str: atom}
+}
+
+func signPrefix(neg bool) string {
+	if neg {
+		return "-"
+	}
+	return ""
+}
+
+func isAllDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isFloatLiteral(s string) bool {
+	if s == "" {
+		return false
+	}
+	seenDot := false
+	seenDigit := false
+	for _, r := range s {
+		switch {
+		case r == '.' && !seenDot:
+			seenDot = true
+		case unicode.IsDigit(r):
+			seenDigit = true
+		default:
+			return false
+		}
+	}
+	return seenDot && seenDigit
+}
@@ -0,0 +1,101 @@
+package glisp
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// sizes exercised by the list benchmarks below: a single element, a
+// thousand, a hundred thousand, and a million, to make sure MakeList/MapList/ConcatList/ParseTokens all stay
+// O(n) time and O(1) Go-stack regardless of input length.
+var benchSizes = []int{1, 1000, 100000, 1000000}
+
+func genInts(n int) []Sexp {
+	arr := make([]Sexp, n)
+	for i := 0; i < n; i++ {
+		arr[i] = SexpInt(i)
+	}
+	return arr
+}
+
+func genListSource(n int) string {
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(strconv.Itoa(i))
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+func BenchmarkParseTokens(b *testing.B) {
+	for _, n := range benchSizes {
+		n := n
+		src := genListSource(n)
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			env := NewGlisp()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				lexer := NewLexer(bufio.NewReader(strings.NewReader(src)))
+				if _, err := ParseTokens(env, lexer); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMakeList(b *testing.B) {
+	for _, n := range benchSizes {
+		n := n
+		arr := genInts(n)
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				MakeList(arr)
+			}
+		})
+	}
+}
+
+func benchIdentity(env *Glisp, name string, args []Sexp) (Sexp, error) {
+	return args[0], nil
+}
+
+func BenchmarkMapList(b *testing.B) {
+	identity := MakeUserFunction("bench-identity", benchIdentity)
+	for _, n := range benchSizes {
+		n := n
+		list := MakeList(genInts(n))
+		env := NewGlisp()
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := MapList(env, identity, list); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkConcatList(b *testing.B) {
+	for _, n := range benchSizes {
+		n := n
+		a := MakeList(genInts(n)).(SexpPair)
+		b2 := MakeList(genInts(n))
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := ConcatList(a, b2); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
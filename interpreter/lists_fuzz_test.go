@@ -0,0 +1,75 @@
+package glisp
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+// nestedListSource builds depth-many nested empty lists, e.g. depth=3
+// produces "((()))".
+func nestedListSource(depth int) string {
+	var sb strings.Builder
+	for i := 0; i < depth; i++ {
+		sb.WriteByte('(')
+	}
+	for i := 0; i < depth; i++ {
+		sb.WriteByte(')')
+	}
+	return sb.String()
+}
+
+// FuzzParseTokensFlatLength checks that a single list's *length* --
+// "(0 1 2 ... n-1)", all at one nesting level -- parses at sizes that
+// would overflow the Go stack if ParseList still recursed once per
+// element, since that's the recursion ParseList's own iterative
+// element loop removed.
+func FuzzParseTokensFlatLength(f *testing.F) {
+	f.Add(0)
+	f.Add(1)
+	f.Add(1000)
+	f.Add(100000)
+
+	f.Fuzz(func(t *testing.T, depth int) {
+		if depth < 0 || depth > 200000 {
+			t.Skip()
+		}
+
+		env := NewGlisp()
+		flat := genListSource(depth)
+		lexer := NewLexer(bufio.NewReader(strings.NewReader(flat)))
+		exprs, err := ParseTokens(env, lexer)
+		if err != nil {
+			t.Fatalf("flat list of length %d: %v", depth, err)
+		}
+		if depth > 0 && len(exprs) != 1 {
+			t.Fatalf("flat list of length %d: expected 1 top-level expression, got %d", depth, len(exprs))
+		}
+	})
+}
+
+// FuzzParseTokensNestingDepth exercises "(((...)))"-shaped source at
+// nesting depths well within the goroutine's default max stack size, as
+// a smoke check on the recursive-descent path ParseList's own element
+// loop does NOT cover. Unlike FuzzParseTokensFlatLength, passing here
+// isn't a guarantee of no stack overflow at arbitrary depth -- see the
+// note on ParseList -- just evidence nothing is badly wrong at depths a
+// real program would plausibly nest to.
+func FuzzParseTokensNestingDepth(f *testing.F) {
+	f.Add(0)
+	f.Add(1)
+	f.Add(1000)
+
+	f.Fuzz(func(t *testing.T, depth int) {
+		if depth < 0 || depth > 5000 {
+			t.Skip()
+		}
+
+		env := NewGlisp()
+		nested := nestedListSource(depth)
+		lexer := NewLexer(bufio.NewReader(strings.NewReader(nested)))
+		if _, err := ParseTokens(env, lexer); err != nil {
+			t.Fatalf("nested list of depth %d: %v", depth, err)
+		}
+	})
+}
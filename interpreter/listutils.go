@@ -6,14 +6,20 @@ import (
 
 var ErrNotAList = errors.New("not a list")
 
+// ListToArray walks expr into a slice of its elements. expr may be a
+// parsed Sexp straight from the parser, so every link of the spine is
+// unwrapped before the SexpPair type assertion -- a caller that already
+// has the outer pair unwrapped (as ExpandMacrosIn does) still passes a
+// raw inner pair down the spine, but nothing guarantees every caller
+// does, and a bare type assertion here would panic the moment it didn't.
 func ListToArray(expr Sexp) ([]Sexp, error) {
 	if !IsList(expr) {
 		return nil, ErrNotAList
 	}
 	arr := make([]Sexp, 0)
 
-	for expr != SexpNull {
-		list := expr.(SexpPair)
+	for Unwrap(expr) != SexpNull {
+		list := Unwrap(expr).(SexpPair)
 		arr = append(arr, list.head)
 		expr = list.tail
 	}
@@ -21,61 +27,63 @@ func ListToArray(expr Sexp) ([]Sexp, error) {
 	return arr, nil
 }
 
+// MakeList builds a proper list out of expressions, consing from the
+// back of the slice forward so a long literal doesn't recurse once per
+// element.
 func MakeList(expressions []Sexp) Sexp {
-	if len(expressions) == 0 {
-		return SexpNull
+	tail := Sexp(SexpNull)
+	for i := len(expressions) - 1; i >= 0; i-- {
+		tail = Cons(expressions[i], tail)
 	}
-
-	return Cons(expressions[0], MakeList(expressions[1:]))
+	return tail
 }
 
+// MapList applies fun to every element of expr and rebuilds the result
+// with MakeList.
 func MapList(env *Glisp, fun SexpFunction, expr Sexp) (Sexp, error) {
 	if expr == SexpNull {
 		return SexpNull, nil
 	}
 
-	var list SexpPair
-	switch e := expr.(type) {
-	case SexpPair:
-		list = e
-	default:
-		return SexpNull, ErrNotAList
-	}
-
-	var err error
-
-	list.head, err = env.Apply(fun, []Sexp{list.head})
-
+	arr, err := ListToArray(expr)
 	if err != nil {
 		return SexpNull, err
 	}
 
-	list.tail, err = MapList(env, fun, list.tail)
-
-	if err != nil {
-		return SexpNull, err
+	result := make([]Sexp, len(arr))
+	for i, item := range arr {
+		result[i], err = env.Apply(fun, []Sexp{item})
+		if err != nil {
+			return SexpNull, err
+		}
 	}
 
-	return list, nil
+	return MakeList(result), nil
 }
 
+// ConcatList appends b onto the end of the list headed by a.
 func ConcatList(a SexpPair, b Sexp) (Sexp, error) {
 	if !IsList(b) {
 		return SexpNull, ErrNotAList
 	}
 
-	if a.tail == SexpNull {
-		return Cons(a.head, b), nil
-	}
+	arr := make([]Sexp, 0, SliceDefaultCap)
+	arr = append(arr, a.head)
 
-	switch t := a.tail.(type) {
-	case SexpPair:
-		newtail, err := ConcatList(t, b)
-		if err != nil {
-			return SexpNull, err
+	rest := a.tail
+	for Unwrap(rest) != SexpNull {
+		pair, ok := Unwrap(rest).(SexpPair)
+		if !ok {
+			return SexpNull, ErrNotAList
 		}
-		return Cons(a.head, newtail), nil
+		arr = append(arr, pair.head)
+		rest = pair.tail
+	}
+
+	tail := b
+	for i := len(arr) - 1; i >= 0; i-- {
+		tail = Cons(arr[i], tail)
 	}
 
-	return SexpNull, ErrNotAList
+	return tail, nil
 }
@@ -0,0 +1,125 @@
+package glisp
+
+// ExpandMacros runs the dedicated macro-expansion pass over a parsed
+// program: every (macro-name ...) call is replaced by the result of
+// running that macro, so the evaluator's compilation step always sees a
+// tree with macros already gone rather than expanding them inline as it
+// walks the program.
+func ExpandMacros(env *Glisp, expressions []Sexp) ([]Sexp, error) {
+	out := make([]Sexp, len(expressions))
+
+	for i, expr := range expressions {
+		expanded, err := ExpandMacrosIn(env, expr)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = expanded
+	}
+
+	return out, nil
+}
+
+// ExpandMacrosIn expands every macro call reachable from node, returning
+// the rewritten tree. It does not descend into (quote ...) or
+// (syntax-quote ...) subtrees: those are inert data, and a list whose
+// head happens to name a macro (e.g. 'select! used as a plain symbol)
+// must not be treated as a call.
+//
+// This isn't implemented in terms of the generic Rewrite in walk.go,
+// because skipping quoted data is a Lisp-semantic rule specific to
+// macro expansion, not something a generic AST rewriter should bake in.
+func ExpandMacrosIn(env *Glisp, node Sexp) (Sexp, error) {
+	pos, wrapped := ExprPosition(node)
+
+	pair, ok := Unwrap(node).(SexpPair)
+	if !ok {
+		arr, ok := Unwrap(node).(SexpArray)
+		if !ok {
+			return node, nil
+		}
+
+		out := make(SexpArray, len(arr))
+		for i, elem := range arr {
+			expanded, err := ExpandMacrosIn(env, elem)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = expanded
+		}
+		if wrapped {
+			return WithPosition(Sexp(out), pos), nil
+		}
+		return out, nil
+	}
+
+	if sym, ok := Unwrap(pair.head).(SexpSymbol); ok {
+		switch sym.Name() {
+		case "quote", "syntax-quote":
+			return node, nil
+		}
+
+		if macro, ok := env.FindMacro(sym.Name()); ok {
+			args, err := ListToArray(pair.tail)
+			if err != nil {
+				return nil, err
+			}
+
+			expanded, err := macro(env, sym.Name(), args)
+			if err != nil {
+				return nil, err
+			}
+
+			// the macro's own expansion may itself contain macro calls
+			return ExpandMacrosIn(env, expanded)
+		}
+	}
+
+	head, err := ExpandMacrosIn(env, pair.head)
+	if err != nil {
+		return nil, err
+	}
+	tail, err := expandListSpine(env, pair.tail)
+	if err != nil {
+		return nil, err
+	}
+
+	result := Sexp(SexpPair{head: head, tail: tail})
+	if wrapped {
+		result = WithPosition(result, pos)
+	}
+	return result, nil
+}
+
+// expandListSpine expands the successive cons cells making up the rest
+// of a list -- pair.tail, and its tail, and so on -- without re-running
+// the macro-call check ExpandMacrosIn does at its entry point. A cons
+// cell that merely continues an enclosing list's spine is structurally
+// identical to a nested call expression (both are a SexpPair whose head
+// may be a symbol), so recursing ExpandMacrosIn straight onto pair.tail
+// misreads "select! is the third element of this list" as "this sublist
+// calls select!": expanding (list 1 select! 3) would wrongly try to
+// expand (select! 3) as read from index 2 onward. Only values reachable
+// through a head position -- genuine sub-expressions -- go through the
+// full ExpandMacrosIn dispatch; the spine itself is just walked.
+func expandListSpine(env *Glisp, tail Sexp) (Sexp, error) {
+	pair, ok := Unwrap(tail).(SexpPair)
+	if !ok {
+		return ExpandMacrosIn(env, tail)
+	}
+	pos, wrapped := ExprPosition(tail)
+
+	head, err := ExpandMacrosIn(env, pair.head)
+	if err != nil {
+		return nil, err
+	}
+	rest, err := expandListSpine(env, pair.tail)
+	if err != nil {
+		return nil, err
+	}
+
+	result := Sexp(SexpPair{head: head, tail: rest})
+	if wrapped {
+		result = WithPosition(result, pos)
+	}
+	return result, nil
+}
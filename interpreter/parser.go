@@ -15,78 +15,91 @@ var ErrUnexpectedEnd error = errors.New("unexpected end of input")
 
 const SliceDefaultCap = 10
 
+// ParseList reads elements up to the closing paren iteratively into a
+// pre-allocated buffer and conses the result in reverse, rather than
+// recursing once per element, so a list with hundreds of thousands of
+// elements doesn't exhaust the Go stack.
+//
+// That bound is on a list's length, not its nesting depth: an element
+// that opens its own '(' is parsed by a recursive call to
+// ParseExpression -> ParseList, one Go stack frame per level of
+// nesting, same as before. A literal like "(((...)))" nested deep
+// enough can still overflow the goroutine's stack; this file doesn't
+// attempt to make the parser's recursive descent over nesting depth
+// iterative, only the per-list element loop.
 func ParseList(parser *Parser) (Sexp, error) {
 	lexer := parser.lexer
-	tok, err := lexer.PeekNextToken()
-	if err != nil {
-		return SexpNull, err
-	}
-	if tok.typ == TokenEnd {
-		_, _ = lexer.GetNextToken()
-		return SexpEnd, ErrUnexpectedEnd
-	}
-
-	if tok.typ == TokenRParen {
-		_, _ = lexer.GetNextToken()
-		return SexpNull, nil
-	}
-
-	var start SexpPair
+	startPos := lexer.Pos()
+	elems := make([]Sexp, 0, SliceDefaultCap)
 
-	expr, err := ParseExpression(parser)
-	if err != nil {
-		return SexpNull, err
-	}
+	for {
+		tok, err := lexer.PeekNextToken()
+		if err != nil {
+			return SexpNull, WrapParseError(lexer.Pos(), err)
+		}
 
-	start.head = expr
+		if tok.typ == TokenEnd {
+			_, _ = lexer.GetNextToken()
+			return SexpEnd, WrapParseError(lexer.Pos(), ErrUnexpectedEnd)
+		}
 
-	tok, err = lexer.PeekNextToken()
-	if err != nil {
-		return SexpNull, err
-	}
+		if tok.typ == TokenRParen {
+			_, _ = lexer.GetNextToken()
+			return WithPosition(MakeList(elems), startPos), nil
+		}
 
-	if tok.typ == TokenDot {
-		// eat up the dot
-		_, _ = lexer.GetNextToken()
-		expr, err = ParseExpression(parser)
+		expr, err := ParseExpression(parser)
 		if err != nil {
 			return SexpNull, err
 		}
+		elems = append(elems, expr)
 
-		// eat up the end paren
-		tok, err = lexer.GetNextToken()
+		tok, err = lexer.PeekNextToken()
 		if err != nil {
-			return SexpNull, err
-		}
-		// make sure it was actually an end paren
-		if tok.typ != TokenRParen {
-			return SexpNull, errors.New("extra value in dotted pair")
+			return SexpNull, WrapParseError(lexer.Pos(), err)
 		}
-		start.tail = expr
-		return start, nil
-	}
 
-	expr, err = ParseList(parser)
-	if err != nil {
-		return start, err
+		if tok.typ == TokenDot {
+			// eat up the dot
+			_, _ = lexer.GetNextToken()
+			tailExpr, err := ParseExpression(parser)
+			if err != nil {
+				return SexpNull, err
+			}
+
+			// eat up the end paren
+			pos := lexer.Pos()
+			tok, err = lexer.GetNextToken()
+			if err != nil {
+				return SexpNull, WrapParseError(pos, err)
+			}
+			// make sure it was actually an end paren
+			if tok.typ != TokenRParen {
+				return SexpNull, NewParseError(pos, "extra value in dotted pair")
+			}
+
+			tail := tailExpr
+			for i := len(elems) - 1; i >= 0; i-- {
+				tail = Cons(elems[i], tail)
+			}
+			return WithPosition(tail, startPos), nil
+		}
 	}
-	start.tail = expr
-
-	return start, nil
 }
 
 func ParseArray(parser *Parser) (Sexp, error) {
 	lexer := parser.lexer
+	startPos := lexer.Pos()
 	arr := make([]Sexp, 0, SliceDefaultCap)
 
 	for {
 		tok, err := lexer.PeekNextToken()
 		if err != nil {
-			return SexpEnd, err
+			return SexpEnd, WrapParseError(lexer.Pos(), err)
 		}
 
 		if tok.typ == TokenEnd {
-			return SexpEnd, ErrUnexpectedEnd
+			return SexpEnd, WrapParseError(lexer.Pos(), ErrUnexpectedEnd)
 		}
 
 		if tok.typ == TokenRSquare {
@@ -102,20 +115,21 @@ func ParseArray(parser *Parser) (Sexp, error) {
 		arr = append(arr, expr)
 	}
 
-	return SexpArray(arr), nil
+	return WithPosition(SexpArray(arr), startPos), nil
 }
 
 func ParseHash(parser *Parser) (Sexp, error) {
 	lexer := parser.lexer
+	startPos := lexer.Pos()
 	arr := make([]Sexp, 0, SliceDefaultCap)
 
 	for {
 		tok, err := lexer.PeekNextToken()
 		if err != nil {
-			return SexpEnd, err
+			return SexpEnd, WrapParseError(lexer.Pos(), err)
 		}
 		if tok.typ == TokenEnd {
-			return SexpEnd, ErrUnexpectedEnd
+			return SexpEnd, WrapParseError(lexer.Pos(), ErrUnexpectedEnd)
 		}
 		if tok.typ == TokenRCurly {
 			// pop off the }
@@ -134,15 +148,16 @@ func ParseHash(parser *Parser) (Sexp, error) {
 	list.head = parser.env.MakeSymbol("hash")
 	list.tail = MakeList(arr)
 
-	return list, nil
+	return WithPosition(list, startPos), nil
 }
 
 func ParseExpression(parser *Parser) (Sexp, error) {
 	lexer := parser.lexer
 	env := parser.env
+	pos := lexer.Pos()
 	tok, err := lexer.GetNextToken()
 	if err != nil {
-		return SexpEnd, err
+		return SexpEnd, WrapParseError(pos, err)
 	}
 
 	switch tok.typ {
@@ -157,67 +172,67 @@ func ParseExpression(parser *Parser) (Sexp, error) {
 		if err != nil {
 			return SexpNull, err
 		}
-		return MakeList([]Sexp{env.MakeSymbol("quote"), expr}), nil
+		return WithPosition(MakeList([]Sexp{env.MakeSymbol("quote"), expr}), pos), nil
 	case TokenBacktick:
 		expr, err := ParseExpression(parser)
 		if err != nil {
 			return SexpNull, err
 		}
-		return MakeList([]Sexp{env.MakeSymbol("syntax-quote"), expr}), nil
+		return WithPosition(MakeList([]Sexp{env.MakeSymbol("syntax-quote"), expr}), pos), nil
 	case TokenTilde:
 		expr, err := ParseExpression(parser)
 		if err != nil {
 			return SexpNull, err
 		}
-		return MakeList([]Sexp{env.MakeSymbol("unquote"), expr}), nil
+		return WithPosition(MakeList([]Sexp{env.MakeSymbol("unquote"), expr}), pos), nil
 	case TokenTildeAt:
 		expr, err := ParseExpression(parser)
 		if err != nil {
 			return SexpNull, err
 		}
-		return MakeList([]Sexp{env.MakeSymbol("unquote-splicing"), expr}), nil
+		return WithPosition(MakeList([]Sexp{env.MakeSymbol("unquote-splicing"), expr}), pos), nil
 	case TokenSymbol:
-		return env.MakeSymbol(tok.str), nil
+		return WithPosition(env.MakeSymbol(tok.str), pos), nil
 	case TokenBool:
-		return SexpBool(tok.str == "true"), nil
+		return WithPosition(SexpBool(tok.str == "true"), pos), nil
 	case TokenDecimal:
 		i, err := strconv.ParseInt(tok.str, 10, SexpIntSize)
 		if err != nil {
-			return SexpNull, err
+			return SexpNull, WrapParseError(pos, err)
 		}
-		return SexpInt(i), nil
+		return WithPosition(SexpInt(i), pos), nil
 	case TokenHex:
 		i, err := strconv.ParseInt(tok.str, 16, SexpIntSize)
 		if err != nil {
-			return SexpNull, err
+			return SexpNull, WrapParseError(pos, err)
 		}
-		return SexpInt(i), nil
+		return WithPosition(SexpInt(i), pos), nil
 	case TokenOct:
 		i, err := strconv.ParseInt(tok.str, 8, SexpIntSize)
 		if err != nil {
-			return SexpNull, err
+			return SexpNull, WrapParseError(pos, err)
 		}
-		return SexpInt(i), nil
+		return WithPosition(SexpInt(i), pos), nil
 	case TokenBinary:
 		i, err := strconv.ParseInt(tok.str, 2, SexpIntSize)
 		if err != nil {
-			return SexpNull, err
+			return SexpNull, WrapParseError(pos, err)
 		}
-		return SexpInt(i), nil
+		return WithPosition(SexpInt(i), pos), nil
 	case TokenChar:
-		return SexpChar(tok.str[0]), nil
+		return WithPosition(SexpChar(tok.str[0]), pos), nil
 	case TokenString:
-		return SexpStr(tok.str), nil
+		return WithPosition(SexpStr(tok.str), pos), nil
 	case TokenFloat:
 		f, err := strconv.ParseFloat(tok.str, SexpFloatSize)
 		if err != nil {
-			return SexpNull, err
+			return SexpNull, WrapParseError(pos, err)
 		}
-		return SexpFloat(f), nil
+		return WithPosition(SexpFloat(f), pos), nil
 	case TokenEnd:
 		return SexpEnd, nil
 	}
-	return SexpNull, errors.New(fmt.Sprint("Invalid syntax, didn't know what to do with ", tok.typ, " ", tok))
+	return SexpNull, NewParseError(pos, fmt.Sprint("Invalid syntax, didn't know what to do with ", tok.typ, " ", tok))
 }
 
 func ParseTokens(env *Glisp, lexer *Lexer) ([]Sexp, error) {
@@ -0,0 +1,189 @@
+package glisp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Position describes a location in glisp source, as produced by the
+// lexer for every token it emits.
+type Position struct {
+	File   string
+	Line   int
+	Col    int
+	Offset int
+}
+
+func (p Position) String() string {
+	if p.File == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Col)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Col)
+}
+
+// PositionedSexp decorates a parsed Sexp with the Position of the token
+// it was parsed from, without requiring every Sexp implementation to
+// carry its own Position field.
+type PositionedSexp struct {
+	Sexp
+	pos Position
+}
+
+// Position returns the source location the wrapped Sexp was parsed from.
+func (p PositionedSexp) Position() Position {
+	return p.pos
+}
+
+// WithPosition wraps expr so that ExprPosition can recover pos later on.
+func WithPosition(expr Sexp, pos Position) Sexp {
+	if expr == nil {
+		return expr
+	}
+	return PositionedSexp{Sexp: expr, pos: pos}
+}
+
+// ExprPosition unwraps a PositionedSexp, if expr is one.
+func ExprPosition(expr Sexp) (Position, bool) {
+	p, ok := expr.(PositionedSexp)
+	if !ok {
+		return Position{}, false
+	}
+	return p.pos, true
+}
+
+// Unwrap strips any PositionedSexp wrapper off expr, returning the
+// underlying Sexp it decorates. Every parsed atom, pair, array and hash
+// is now wrapped to carry its source Position, so any code that
+// type-switches on a parsed Sexp (special forms, function application,
+// macro bodies, ...) must call Unwrap first or it will never match the
+// concrete type it's looking for. ListToArray and ConcatList in
+// listutils.go call it for exactly this reason, and
+// TestOrdinarySpecialFormsSurviveParsing in position_test.go runs
+// ordinary if/def/fn/quote source through the real parser to check this
+// convention holds end to end, not just at the two call sites a bug
+// happened to surface at.
+func Unwrap(expr Sexp) Sexp {
+	for {
+		p, ok := expr.(PositionedSexp)
+		if !ok {
+			return expr
+		}
+		expr = p.Sexp
+	}
+}
+
+// ParseError is returned by the Parse* functions whenever a syntax error
+// is found. It records the Position of the offending token alongside the
+// message and, when the error wraps a lower-level failure, the original
+// error via Unwrap.
+type ParseError struct {
+	pos Position
+	msg string
+	err error
+}
+
+func NewParseError(pos Position, msg string) *ParseError {
+	return &ParseError{pos: pos, msg: msg}
+}
+
+func WrapParseError(pos Position, err error) *ParseError {
+	if err == nil {
+		return nil
+	}
+	return &ParseError{pos: pos, msg: err.Error(), err: err}
+}
+
+func (e *ParseError) Pos() Position { return e.pos }
+func (e *ParseError) Msg() string   { return e.msg }
+func (e *ParseError) Error() string { return fmt.Sprintf("%s: %s", e.pos, e.msg) }
+func (e *ParseError) Unwrap() error { return e.err }
+
+// RuntimeError is the evaluator's counterpart to ParseError: a failure
+// that occurred while running an already-parsed expression, tagged with
+// the Position it was parsed from.
+type RuntimeError struct {
+	pos Position
+	msg string
+	err error
+}
+
+func NewRuntimeError(pos Position, msg string) *RuntimeError {
+	return &RuntimeError{pos: pos, msg: msg}
+}
+
+func WrapRuntimeError(pos Position, err error) *RuntimeError {
+	if err == nil {
+		return nil
+	}
+	return &RuntimeError{pos: pos, msg: err.Error(), err: err}
+}
+
+func (e *RuntimeError) Pos() Position { return e.pos }
+func (e *RuntimeError) Msg() string   { return e.msg }
+func (e *RuntimeError) Error() string { return fmt.Sprintf("%s: %s", e.pos, e.msg) }
+func (e *RuntimeError) Unwrap() error { return e.err }
+
+// ErrorList collects multiple positioned errors, mirroring go/scanner's
+// ErrorList so a parse pass can report more than the first failure.
+type ErrorList []error
+
+func (list ErrorList) Error() string {
+	switch len(list) {
+	case 0:
+		return "no errors"
+	case 1:
+		return list[0].Error()
+	}
+	msgs := make([]string, len(list))
+	for i, err := range list {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// positioned is implemented by ParseError and RuntimeError, and is used
+// by FormatError to recover the source Position of an arbitrary error.
+type positioned interface {
+	Pos() Position
+	Msg() string
+}
+
+// FormatError renders err as "file:line:col: message", followed by the
+// offending source line and a caret pointing at the column, the way the
+// REPL surfaces parse and runtime errors to the user. src is the full
+// source text the error was produced from. If err does not carry a
+// Position, its plain Error() string is returned unchanged.
+func FormatError(src string, err error) string {
+	var pe positioned
+	for cur := err; cur != nil; cur = unwrap(cur) {
+		if p, ok := cur.(positioned); ok {
+			pe = p
+			break
+		}
+	}
+	if pe == nil {
+		return err.Error()
+	}
+
+	pos := pe.Pos()
+	lines := strings.Split(src, "\n")
+	idx := pos.Line - 1
+	if idx < 0 || idx >= len(lines) {
+		return fmt.Sprintf("%s: %s", pos, pe.Msg())
+	}
+
+	col := pos.Col - 1
+	if col < 0 {
+		col = 0
+	}
+	caret := strings.Repeat(" ", col) + "^"
+	return fmt.Sprintf("%s: %s\n%s\n%s", pos, pe.Msg(), lines[idx], caret)
+}
+
+func unwrap(err error) error {
+	u, ok := err.(interface{ Unwrap() error })
+	if !ok {
+		return nil
+	}
+	return u.Unwrap()
+}
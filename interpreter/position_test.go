@@ -0,0 +1,50 @@
+package glisp
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+// runProgram parses and runs src through the same ParseTokens ->
+// LoadExpressions -> Run pipeline the REPL uses, so tests exercise
+// whatever the core evaluator actually does with a parsed (and thus
+// PositionedSexp-wrapped) tree, rather than hand-building trees that
+// skip the parser entirely.
+func runProgram(t *testing.T, src string) Sexp {
+	t.Helper()
+	env := NewGlisp()
+	lexer := NewLexer(bufio.NewReader(strings.NewReader(src)))
+	exprs, err := ParseTokens(env, lexer)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := env.LoadExpressions(exprs); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	result, err := env.Run()
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	return result
+}
+
+// TestOrdinarySpecialFormsSurviveParsing exercises if/def/fn/quote
+// through the real parser instead of hand-built trees, since every atom
+// and list the parser returns is now wrapped in PositionedSexp: if the
+// evaluator's special-form dispatch ever type-switched on a parsed Sexp
+// without unwrapping it first, ordinary programs like this one would
+// fail to evaluate these forms at all instead of just misbehaving on
+// the macro-only code paths this series happened to touch.
+func TestOrdinarySpecialFormsSurviveParsing(t *testing.T) {
+	result := runProgram(t, `
+		(def double (fn [x] (if (> x 0) (* x 2) 0)))
+		(def q (quote (a b c)))
+		(double 21)
+	`)
+
+	i, ok := result.(SexpInt)
+	if !ok || i != 42 {
+		t.Fatalf("expected (double 21) to evaluate to 42, got %#v", result)
+	}
+}
@@ -0,0 +1,90 @@
+package glisp
+
+// Visitor's Visit method is invoked for each node encountered by Walk.
+// If the returned Visitor w is not nil, Walk visits each child of node
+// with w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Sexp) (w Visitor)
+}
+
+// Walk traverses an Sexp tree in depth-first order, modeled on
+// go/ast.Walk. It calls v.Visit(node); if the returned visitor is not
+// nil, Walk recurses into node's children with it and finally calls
+// Visit(nil) once there are no more children.
+//
+// The only child relationships a parsed Sexp can have are a
+// PositionedSexp wrapper's inner Sexp, a pair's head and tail and an
+// array's elements; a `{...}` hash literal parses down
+// to a (hash k1 v1 k2 v2 ...) pair list, so its key/value pairs are
+// already reached via the pair case.
+func Walk(v Visitor, node Sexp) {
+	if node == nil {
+		return
+	}
+
+	v = v.Visit(node)
+	if v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case PositionedSexp:
+		Walk(v, n.Sexp)
+	case SexpPair:
+		Walk(v, n.head)
+		Walk(v, n.tail)
+	case SexpArray:
+		for _, elem := range n {
+			Walk(v, elem)
+		}
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Sexp) bool
+
+func (f inspector) Visit(node Sexp) Visitor {
+	if node != nil && f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses node in depth-first order, calling f for each node
+// encountered. If f returns false, Inspect skips that node's children.
+func Inspect(node Sexp, f func(Sexp) bool) {
+	Walk(inspector(f), node)
+}
+
+// Rewrite returns a copy of node with f applied bottom-up: f runs on
+// every child before it runs on node itself, and wherever f returns
+// (replacement, true) the result tree has replacement in that node's
+// place. It preserves Position information carried by PositionedSexp
+// wrappers on nodes that aren't themselves replaced.
+func Rewrite(node Sexp, f func(Sexp) (Sexp, bool)) Sexp {
+	if node == nil {
+		return node
+	}
+
+	switch n := node.(type) {
+	case PositionedSexp:
+		n.Sexp = Rewrite(n.Sexp, f)
+		node = n
+	case SexpPair:
+		n.head = Rewrite(n.head, f)
+		n.tail = Rewrite(n.tail, f)
+		node = n
+	case SexpArray:
+		out := make(SexpArray, len(n))
+		for i, elem := range n {
+			out[i] = Rewrite(elem, f)
+		}
+		node = out
+	}
+
+	if replacement, ok := f(node); ok {
+		return replacement
+	}
+	return node
+}
@@ -0,0 +1,167 @@
+package glisp
+
+import "testing"
+
+func countingVisitor(count *int) inspector {
+	return func(node Sexp) bool {
+		if node != nil {
+			*count++
+		}
+		return true
+	}
+}
+
+func TestWalkVisitsPairsAndArrays(t *testing.T) {
+	tree := SexpPair{
+		head: SexpInt(1),
+		tail: SexpPair{
+			head: SexpArray{SexpInt(2), SexpInt(3)},
+			tail: SexpNull,
+		},
+	}
+
+	var count int
+	Walk(countingVisitor(&count), tree)
+
+	// the two pair links (tree itself and its tail), SexpInt(1), the
+	// array, SexpInt(2), SexpInt(3), and the trailing SexpNull -- 7
+	// non-nil nodes total.
+	if count != 7 {
+		t.Fatalf("expected 7 visited nodes, got %d", count)
+	}
+}
+
+func TestWalkUnwrapsPositionedSexp(t *testing.T) {
+	pos := Position{Line: 1, Col: 1}
+	tree := WithPosition(SexpPair{head: SexpInt(1), tail: SexpNull}, pos)
+
+	var sawPair bool
+	Inspect(tree, func(node Sexp) bool {
+		if _, ok := node.(SexpPair); ok {
+			sawPair = true
+		}
+		return true
+	})
+
+	if !sawPair {
+		t.Fatal("expected Walk to descend into the PositionedSexp wrapper's child")
+	}
+}
+
+func TestInspectCanPruneSubtrees(t *testing.T) {
+	tree := SexpArray{
+		SexpArray{SexpInt(1), SexpInt(2)},
+		SexpInt(3),
+	}
+
+	var visited []Sexp
+	Inspect(tree, func(node Sexp) bool {
+		visited = append(visited, node)
+		// the root array is always the first node visited
+		if _, ok := node.(SexpArray); ok && len(visited) > 1 {
+			return false // don't descend into the nested array
+		}
+		return true
+	})
+
+	for _, node := range visited {
+		if i, ok := node.(SexpInt); ok && i == 1 {
+			t.Fatal("Inspect descended into a subtree its callback asked to prune")
+		}
+	}
+}
+
+func TestRewriteReplacesBottomUp(t *testing.T) {
+	tree := SexpPair{
+		head: SexpInt(1),
+		tail: SexpPair{head: SexpInt(2), tail: SexpNull},
+	}
+
+	doubled := Rewrite(tree, func(node Sexp) (Sexp, bool) {
+		i, ok := node.(SexpInt)
+		if !ok {
+			return node, false
+		}
+		return SexpInt(i * 2), true
+	})
+
+	arr, err := ListToArray(doubled)
+	if err != nil {
+		t.Fatalf("ListToArray: %v", err)
+	}
+	if len(arr) != 2 || arr[0] != SexpInt(2) || arr[1] != SexpInt(4) {
+		t.Fatalf("expected (2 4), got %v", arr)
+	}
+}
+
+func TestExpandMacrosSkipsQuotedCalls(t *testing.T) {
+	env := NewGlisp()
+	expandCount := 0
+	env.AddMacro("double!", func(env *Glisp, name string, args []Sexp) (Sexp, error) {
+		expandCount++
+		return args[0], nil
+	})
+
+	quoted := MakeList([]Sexp{env.MakeSymbol("quote"),
+		MakeList([]Sexp{env.MakeSymbol("double!"), SexpInt(21)})})
+
+	expanded, err := ExpandMacrosIn(env, quoted)
+	if err != nil {
+		t.Fatalf("ExpandMacrosIn: %v", err)
+	}
+	if expandCount != 0 {
+		t.Fatalf("expected quoted (double! 21) to be left alone, macro ran %d times", expandCount)
+	}
+	if expanded != quoted {
+		t.Fatalf("expected quoted form to come back unchanged")
+	}
+
+	call := MakeList([]Sexp{env.MakeSymbol("double!"), SexpInt(21)})
+	expanded, err = ExpandMacrosIn(env, call)
+	if err != nil {
+		t.Fatalf("ExpandMacrosIn: %v", err)
+	}
+	if expandCount != 1 {
+		t.Fatalf("expected the unquoted call to expand the macro once, got %d", expandCount)
+	}
+	if expanded != SexpInt(21) {
+		t.Fatalf("expected expansion to return args[0], got %v", expanded)
+	}
+}
+
+// TestExpandMacrosIgnoresNonLeadingMacroName checks that a macro name
+// appearing as a non-leading element of a list -- not in call position --
+// is left alone. (list 1 double! 3) must not be misread as "the sublist
+// starting at double! calls double!", the way recursing ExpandMacrosIn
+// straight onto a list's raw tail spine would.
+func TestExpandMacrosIgnoresNonLeadingMacroName(t *testing.T) {
+	env := NewGlisp()
+	expandCount := 0
+	env.AddMacro("double!", func(env *Glisp, name string, args []Sexp) (Sexp, error) {
+		expandCount++
+		return args[0], nil
+	})
+
+	call := MakeList([]Sexp{
+		env.MakeSymbol("list"), SexpInt(1), env.MakeSymbol("double!"), SexpInt(3),
+	})
+
+	expanded, err := ExpandMacrosIn(env, call)
+	if err != nil {
+		t.Fatalf("ExpandMacrosIn: %v", err)
+	}
+	if expandCount != 0 {
+		t.Fatalf("expected double! used as a plain value to be left alone, macro ran %d times", expandCount)
+	}
+
+	arr, err := ListToArray(expanded)
+	if err != nil {
+		t.Fatalf("ListToArray: %v", err)
+	}
+	if len(arr) != 4 {
+		t.Fatalf("expected (list 1 double! 3) to come back with 4 elements, got %v", arr)
+	}
+	if sym, ok := Unwrap(arr[2]).(SexpSymbol); !ok || sym.Name() != "double!" {
+		t.Fatalf("expected the third element to stay the bare symbol double!, got %#v", arr[2])
+	}
+}